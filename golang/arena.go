@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// errArenaFull is returned when an allocation would not fit in the
+// remaining space of an Arena.
+var errArenaFull = errors.New("arena: insufficient space")
+
+// Arena is a fixed-size, append-only byte buffer used to store the keys and
+// values backing a Skiplist. Allocations are served by bumping an atomic
+// offset, so concurrent writers never take a lock and readers that already
+// hold an offset into the arena can keep reading it safely - nothing is
+// ever mutated or freed once written. The whole arena is discarded together
+// with its owning skiplist, e.g. once a MemTable has been flushed.
+type Arena struct {
+	buf    []byte
+	offset atomic.Uint32
+}
+
+// NewArena allocates a new Arena with the given capacity in bytes.
+func NewArena(size int) *Arena {
+	return &Arena{buf: make([]byte, size)}
+}
+
+// alloc reserves n bytes in the arena and returns the offset at which they
+// start. It returns errArenaFull if the arena does not have enough room.
+func (a *Arena) alloc(n int) (uint32, error) {
+	newOffset := a.offset.Add(uint32(n))
+	if int(newOffset) > len(a.buf) {
+		return 0, errArenaFull
+	}
+	return newOffset - uint32(n), nil
+}
+
+// putBytes copies b into the arena and returns the offset it was written
+// at, so it can later be retrieved with getBytes.
+func (a *Arena) putBytes(b []byte) (uint32, error) {
+	offset, err := a.alloc(len(b))
+	if err != nil {
+		return 0, err
+	}
+	copy(a.buf[offset:], b)
+	return offset, nil
+}
+
+// getBytes returns the n bytes stored at offset. The returned slice aliases
+// the arena's backing array and must not be mutated by the caller.
+func (a *Arena) getBytes(offset uint32, n int) []byte {
+	return a.buf[offset : offset+uint32(n)]
+}
+
+// size returns the number of bytes allocated from the arena so far.
+func (a *Arena) size() int64 {
+	return int64(a.offset.Load())
+}
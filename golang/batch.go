@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// batchHeaderLen is the fixed-size header prefixed to a WriteBatch's
+// encoded buffer: an 8-byte base sequence number followed by a 4-byte
+// count of records.
+const batchHeaderLen = 8 + 4
+
+var (
+	errBatchMalformedVarint = errors.New("batch: malformed varint length")
+	errBatchTruncated       = errors.New("batch: truncated record")
+)
+
+// WriteBatch accumulates a sequence of Put/Delete operations into a
+// compact byte-encoded buffer, so they can later be applied to a MemTable
+// (and, eventually, replayed from a WAL record carrying the same bytes)
+// as a single atomic unit.
+//
+// Encoding: [seq:8][count:4] followed by count records of
+// [kind:1][keyLen:varint][key][valLen:varint][value]. A Delete record
+// always encodes a zero-length value.
+type WriteBatch struct {
+	data  []byte
+	count int
+}
+
+// NewWriteBatch returns an empty WriteBatch ready to accumulate operations.
+func NewWriteBatch() *WriteBatch {
+	return &WriteBatch{data: make([]byte, batchHeaderLen)}
+}
+
+// Put appends a Put record for key/value to the batch.
+func (b *WriteBatch) Put(key, value []byte) {
+	b.data = append(b.data, byte(typePut))
+	b.data = appendVarintBytes(b.data, key)
+	b.data = appendVarintBytes(b.data, value)
+	b.count++
+	b.writeCount()
+}
+
+// Delete appends a Delete record for key to the batch.
+func (b *WriteBatch) Delete(key []byte) {
+	b.data = append(b.data, byte(typeDelete))
+	b.data = appendVarintBytes(b.data, key)
+	b.data = appendVarintBytes(b.data, nil)
+	b.count++
+	b.writeCount()
+}
+
+// Reset clears the batch so its buffer can be reused without reallocating.
+func (b *WriteBatch) Reset() {
+	b.data = b.data[:batchHeaderLen]
+	for i := range b.data {
+		b.data[i] = 0
+	}
+	b.count = 0
+}
+
+// writeCount stamps the batch's header with its current record count, so
+// [8:12] of an encoded batch always matches Len() rather than the header
+// promised by the encoding going stale.
+func (b *WriteBatch) writeCount() {
+	binary.LittleEndian.PutUint32(b.data[8:12], uint32(b.count))
+}
+
+// Len returns the number of records in the batch.
+func (b *WriteBatch) Len() int {
+	return b.count
+}
+
+// Size returns the encoded size of the batch in bytes, including the
+// header.
+func (b *WriteBatch) Size() int {
+	return len(b.data)
+}
+
+// setSeq stamps the batch's header with the sequence number its first
+// record was assigned; subsequent records implicitly take seq+1, seq+2,
+// and so on.
+func (b *WriteBatch) setSeq(seq uint64) {
+	binary.LittleEndian.PutUint64(b.data[0:8], seq)
+}
+
+// Seq returns the base sequence number set by setSeq, or 0 if the batch
+// has not been applied yet.
+func (b *WriteBatch) Seq() uint64 {
+	return binary.LittleEndian.Uint64(b.data[0:8])
+}
+
+// Bytes returns the batch's encoded representation, including its header.
+// The returned slice aliases the batch's internal buffer.
+func (b *WriteBatch) Bytes() []byte {
+	return b.data
+}
+
+// Iterator returns a BatchIterator over the batch's records, in the order
+// they were added.
+func (b *WriteBatch) Iterator() *BatchIterator {
+	return &BatchIterator{data: b.data[batchHeaderLen:]}
+}
+
+// BatchIterator walks the records encoded in a WriteBatch or in a WAL
+// record carrying the same encoding.
+type BatchIterator struct {
+	data  []byte
+	kind  valueType
+	key   []byte
+	value []byte
+}
+
+// Next decodes the next record and reports whether one was found. It
+// returns an error if the remaining bytes are not validly encoded.
+func (it *BatchIterator) Next() (bool, error) {
+	if len(it.data) == 0 {
+		return false, nil
+	}
+
+	kind := valueType(it.data[0])
+	rest := it.data[1:]
+
+	key, rest, err := readVarintBytes(rest)
+	if err != nil {
+		return false, err
+	}
+	value, rest, err := readVarintBytes(rest)
+	if err != nil {
+		return false, err
+	}
+
+	it.kind = kind
+	it.key = key
+	it.value = value
+	it.data = rest
+	return true, nil
+}
+
+// Kind returns the record kind (typePut or typeDelete) at the iterator's
+// current position.
+func (it *BatchIterator) Kind() valueType {
+	return it.kind
+}
+
+// Key returns the key at the iterator's current position.
+func (it *BatchIterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the value at the iterator's current position. It is
+// always empty for a Delete record.
+func (it *BatchIterator) Value() []byte {
+	return it.value
+}
+
+func appendVarintBytes(dst, b []byte) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(b)))
+	dst = append(dst, buf[:n]...)
+	dst = append(dst, b...)
+	return dst
+}
+
+func readVarintBytes(data []byte) (b, rest []byte, err error) {
+	n, nn := binary.Uvarint(data)
+	if nn <= 0 {
+		return nil, nil, errBatchMalformedVarint
+	}
+	data = data[nn:]
+	if uint64(len(data)) < n {
+		return nil, nil, errBatchTruncated
+	}
+	return data[:n], data[n:], nil
+}
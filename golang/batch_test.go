@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteBatch_PutDeleteIteration(t *testing.T) {
+	b := NewWriteBatch()
+	b.Put([]byte("k1"), []byte("v1"))
+	b.Delete([]byte("k2"))
+
+	if got := b.Len(); got != 2 {
+		t.Fatalf("Len() = %d, want 2", got)
+	}
+
+	it := b.Iterator()
+
+	ok, err := it.Next()
+	if err != nil || !ok {
+		t.Fatalf("Next() = %v, %v, want true, nil", ok, err)
+	}
+	if it.Kind() != typePut || !bytes.Equal(it.Key(), []byte("k1")) || !bytes.Equal(it.Value(), []byte("v1")) {
+		t.Errorf("first record = (%v, %q, %q), want (typePut, k1, v1)", it.Kind(), it.Key(), it.Value())
+	}
+
+	ok, err = it.Next()
+	if err != nil || !ok {
+		t.Fatalf("Next() = %v, %v, want true, nil", ok, err)
+	}
+	if it.Kind() != typeDelete || !bytes.Equal(it.Key(), []byte("k2")) || len(it.Value()) != 0 {
+		t.Errorf("second record = (%v, %q, %q), want (typeDelete, k2, \"\")", it.Kind(), it.Key(), it.Value())
+	}
+
+	ok, err = it.Next()
+	if err != nil || ok {
+		t.Errorf("Next() at end = %v, %v, want false, nil", ok, err)
+	}
+}
+
+func TestWriteBatch_Reset(t *testing.T) {
+	b := NewWriteBatch()
+	b.Put([]byte("k"), []byte("v"))
+
+	b.Reset()
+
+	if got := b.Len(); got != 0 {
+		t.Errorf("Len() after Reset = %d, want 0", got)
+	}
+	if got := b.Size(); got != batchHeaderLen {
+		t.Errorf("Size() after Reset = %d, want %d", got, batchHeaderLen)
+	}
+}
+
+func TestMemTable_ApplyBatch(t *testing.T) {
+	mt := newTestMemTable(t)
+	mt.Put("existing", []byte("old"))
+
+	b := NewWriteBatch()
+	b.Put([]byte("k1"), []byte("v1"))
+	b.Put([]byte("k2"), []byte("v2"))
+	b.Delete([]byte("existing"))
+
+	if err := mt.ApplyBatch(b); err != nil {
+		t.Fatalf("ApplyBatch() unexpected error: %v", err)
+	}
+
+	checkGet(t, mt, "k1", []byte("v1"), true)
+	checkGet(t, mt, "k2", []byte("v2"), true)
+	checkGet(t, mt, "existing", nil, false)
+
+	if got := mt.Len(); got != 4 {
+		t.Errorf("Len() after ApplyBatch = %d, want 4", got)
+	}
+}
+
+func TestMemTable_ApplyBatchEmpty(t *testing.T) {
+	mt := newTestMemTable(t)
+	if err := mt.ApplyBatch(NewWriteBatch()); err != nil {
+		t.Fatalf("ApplyBatch(empty) unexpected error: %v", err)
+	}
+	if got := mt.Len(); got != 0 {
+		t.Errorf("Len() after empty ApplyBatch = %d, want 0", got)
+	}
+}
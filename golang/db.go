@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultMemTableSize is the approximate size, in bytes, at which DB
+// rotates the active memtable out and freezes it for flushing.
+const defaultMemTableSize = 4 << 20 // 4MB
+
+// defaultMaxImmutableMemTables bounds how many frozen memtables may sit in
+// the flush queue before writers back-pressure, an "append pipeline size"
+// style knob (as used in varlog) that keeps a slow flusher from letting
+// unflushed memtables pile up without bound.
+const defaultMaxImmutableMemTables = 4
+
+// Option configures a DB created with NewDB.
+type Option func(*DB)
+
+// WithMemTableSize overrides the size threshold, in bytes, at which the
+// active memtable is rotated out and frozen.
+func WithMemTableSize(n int64) Option {
+	return func(db *DB) { db.memTableSize = n }
+}
+
+// WithMaxImmutableMemTables overrides how many frozen memtables may sit in
+// the flush queue before Put/Delete/ApplyBatch block to apply
+// backpressure.
+func WithMaxImmutableMemTables(n int) Option {
+	return func(db *DB) { db.maxImmutables = n }
+}
+
+// DB adds lifecycle management on top of MemTable: a single mutable
+// memtable absorbs writes, and once it crosses MemTableSize it is frozen
+// and queued for a background flusher goroutine to drain, while a fresh
+// mutable memtable takes over writes immediately. Reads consult the
+// mutable memtable, then the immutable queue newest-first - this is the
+// glue that turns a single memtable into a real LSM write path, ahead of
+// an on-disk SSTable layer.
+type DB struct {
+	dir           string
+	memTableSize  int64
+	maxImmutables int
+
+	mu          sync.Mutex
+	flushCond   *sync.Cond
+	mutable     *MemTable
+	immutables  []*MemTable // oldest first
+	nextMTableN int
+
+	closed    atomic.Bool
+	closeOnce sync.Once
+	flushDone chan struct{}
+}
+
+// NewDB opens a DB rooted at dir and starts its background flusher.
+func NewDB(dir string, opts ...Option) (*DB, error) {
+	db := &DB{
+		dir:           dir,
+		memTableSize:  defaultMemTableSize,
+		maxImmutables: defaultMaxImmutableMemTables,
+		flushDone:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(db)
+	}
+	db.flushCond = sync.NewCond(&db.mu)
+
+	mt, err := db.newMemTable()
+	if err != nil {
+		return nil, err
+	}
+	db.mutable = mt
+
+	go db.flushLoop()
+	return db, nil
+}
+
+// newMemTable creates a MemTable rooted at the next WAL subdirectory of
+// db.dir.
+func (db *DB) newMemTable() (*MemTable, error) {
+	walDir := filepath.Join(db.dir, fmt.Sprintf("wal-%04d", db.nextMTableN))
+	db.nextMTableN++
+	return NewMemTable(walDir)
+}
+
+// Put applies key/value to the active memtable, rotating it out for
+// flushing first if it has crossed MemTableSize.
+func (db *DB) Put(key string, value []byte) error {
+	mt, err := db.writableMemTable()
+	if err != nil {
+		return err
+	}
+	return mt.Put(key, value)
+}
+
+// Delete applies a tombstone for key to the active memtable, rotating it
+// out for flushing first if it has crossed MemTableSize.
+func (db *DB) Delete(key string) error {
+	mt, err := db.writableMemTable()
+	if err != nil {
+		return err
+	}
+	return mt.Delete(key)
+}
+
+// ApplyBatch applies b to the active memtable, rotating it out for
+// flushing first if it has crossed MemTableSize.
+func (db *DB) ApplyBatch(b *WriteBatch) error {
+	mt, err := db.writableMemTable()
+	if err != nil {
+		return err
+	}
+	return mt.ApplyBatch(b)
+}
+
+// writableMemTable returns the current mutable memtable, freezing it into
+// the immutable queue first if it has grown past MemTableSize. Callers
+// block here while the immutable queue is already at MaxImmutableMemTables,
+// so a slow flusher applies backpressure instead of letting the queue grow
+// without bound.
+func (db *DB) writableMemTable() (*MemTable, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for len(db.immutables) >= db.maxImmutables {
+		db.flushCond.Wait()
+	}
+
+	if db.mutable.Size() >= db.memTableSize {
+		if err := db.rotateLocked(); err != nil {
+			return nil, err
+		}
+	}
+	return db.mutable, nil
+}
+
+// rotateLocked freezes the current mutable memtable onto the immutable
+// queue and swaps in a fresh one. db.mu must be held.
+func (db *DB) rotateLocked() error {
+	fresh, err := db.newMemTable()
+	if err != nil {
+		return err
+	}
+	db.immutables = append(db.immutables, db.mutable)
+	db.mutable = fresh
+	db.flushCond.Broadcast() // wake the flusher
+	return nil
+}
+
+// Get consults the mutable memtable, then the immutable queue newest
+// first. A tombstone found along the way shadows everything older, so the
+// search stops there even though it returns no value. A future SSTable
+// layer will be the final fallback once flushing actually persists data.
+func (db *DB) Get(key string) ([]byte, bool, error) {
+	db.mu.Lock()
+	tables := make([]*MemTable, 0, len(db.immutables)+1)
+	tables = append(tables, db.mutable)
+	for i := len(db.immutables) - 1; i >= 0; i-- {
+		tables = append(tables, db.immutables[i])
+	}
+	db.mu.Unlock()
+
+	for _, mt := range tables {
+		value, found, isTombstone := mt.getRaw(key)
+		if found {
+			return value, true, nil
+		}
+		if isTombstone {
+			return nil, false, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// FlushAsync freezes the mutable memtable into the immutable queue
+// immediately, regardless of its size, and returns without waiting for the
+// background flusher to drain it. It is a no-op if the mutable memtable is
+// empty.
+//
+// Until flushOne's TODO lands, this is not a durability operation a caller
+// can rely on for read-visibility: once the background flusher drains the
+// frozen memtable, its keys drop out of Get entirely rather than moving to
+// an on-disk SSTable.
+func (db *DB) FlushAsync() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for len(db.immutables) >= db.maxImmutables {
+		db.flushCond.Wait()
+	}
+	if db.mutable.Len() == 0 {
+		return nil
+	}
+	return db.rotateLocked()
+}
+
+// Flush behaves like FlushAsync but blocks until the memtable it freezes
+// (or, if the mutable memtable was already empty, the newest memtable
+// already queued) has been flushed.
+//
+// The same caveat as FlushAsync applies: until there is an SSTable layer
+// for flushed data to land in, waiting for Flush to return only guarantees
+// the memtable's WAL has been sealed, not that its keys remain readable.
+func (db *DB) Flush() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for len(db.immutables) >= db.maxImmutables {
+		db.flushCond.Wait()
+	}
+
+	var target *MemTable
+	switch {
+	case db.mutable.Len() > 0:
+		target = db.mutable
+		if err := db.rotateLocked(); err != nil {
+			return err
+		}
+	case len(db.immutables) > 0:
+		target = db.immutables[len(db.immutables)-1]
+	default:
+		return nil
+	}
+
+	for db.isQueuedLocked(target) {
+		db.flushCond.Wait()
+	}
+	return nil
+}
+
+func (db *DB) isQueuedLocked(mt *MemTable) bool {
+	for _, im := range db.immutables {
+		if im == mt {
+			return true
+		}
+	}
+	return false
+}
+
+// flushLoop drains the immutable queue in the background, oldest first,
+// until Close is called and the queue runs dry.
+func (db *DB) flushLoop() {
+	defer close(db.flushDone)
+	for {
+		db.mu.Lock()
+		for len(db.immutables) == 0 {
+			if db.closed.Load() {
+				db.mu.Unlock()
+				return
+			}
+			db.flushCond.Wait()
+		}
+		mt := db.immutables[0]
+		db.mu.Unlock()
+
+		db.flushOne(mt)
+
+		db.mu.Lock()
+		db.immutables = db.immutables[1:]
+		db.flushCond.Broadcast() // wake writers/Flush callers waiting on us
+		db.mu.Unlock()
+	}
+}
+
+// flushOne persists a frozen memtable. Until the SSTable layer exists, a
+// real implementation would walk mt.NewIterator() to write a sorted
+// SSTable here; for now flushing just seals the memtable's WAL, since its
+// entries are never written again once the memtable has been handed off.
+//
+// TODO(chunk0-6): this drops the memtable on the floor with nothing durable
+// to read it back from, so any key only present in a flushed memtable
+// becomes unreachable via Get the moment flushLoop pops it off the
+// immutable queue - see TestDB_FlushDropsReadVisibilityUntilSSTables. Wire
+// this up to write a real SSTable, and give DB.Get a place to fall through
+// to, before relying on Flush/FlushAsync for anything but freeing up the
+// write path.
+func (db *DB) flushOne(mt *MemTable) {
+	_ = mt.wal.Close()
+}
+
+// Close stops the background flusher once it has drained whatever is
+// currently queued, and closes the mutable memtable's WAL.
+func (db *DB) Close() error {
+	db.closeOnce.Do(func() {
+		db.mu.Lock()
+		db.closed.Store(true)
+		db.flushCond.Broadcast()
+		db.mu.Unlock()
+		<-db.flushDone
+	})
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.mutable.wal.Close()
+}
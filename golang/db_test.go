@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestDB(t *testing.T, opts ...Option) *DB {
+	t.Helper()
+	db, err := NewDB(t.TempDir(), opts...)
+	if err != nil {
+		t.Fatalf("NewDB() unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Close() unexpected error: %v", err)
+		}
+	})
+	return db
+}
+
+func TestDB_PutGet(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.Put("key", []byte("value")); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	value, found, err := db.Get("key")
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if !found || string(value) != "value" {
+		t.Errorf("Get() = (%q, %v), want (value, true)", value, found)
+	}
+}
+
+func TestDB_RotatesOnSizeThreshold(t *testing.T) {
+	db := newTestDB(t, WithMemTableSize(16))
+
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("k%d", i)
+		if err := db.Put(key, []byte("some-value")); err != nil {
+			t.Fatalf("Put(%q) unexpected error: %v", key, err)
+		}
+	}
+
+	db.mu.Lock()
+	n := db.nextMTableN
+	db.mu.Unlock()
+
+	if n < 2 {
+		t.Errorf("expected at least one rotation past the size threshold, nextMTableN = %d", n)
+	}
+
+	// The most recently written key lives in the active mutable memtable
+	// and must be visible regardless of how the background flusher has
+	// progressed through the immutable queue.
+	_, found, err := db.Get("k4")
+	if err != nil {
+		t.Fatalf("Get(k4) unexpected error: %v", err)
+	}
+	if !found {
+		t.Error("Get(k4) not found after rotation")
+	}
+}
+
+func TestDB_DeleteShadowsOlderMemTable(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.Put("key", []byte("value")); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if err := db.FlushAsync(); err != nil {
+		t.Fatalf("FlushAsync() unexpected error: %v", err)
+	}
+	if err := db.Delete("key"); err != nil {
+		t.Fatalf("Delete() unexpected error: %v", err)
+	}
+
+	_, found, err := db.Get("key")
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if found {
+		t.Error("Get() found a key shadowed by a newer tombstone")
+	}
+}
+
+// TestDB_FlushDropsReadVisibilityUntilSSTables pins down flushOne's current,
+// temporary behavior: without an SSTable layer to flush into, a key that
+// only lives in a flushed memtable becomes unreachable via Get once the
+// background flusher drains it. This is tracked by the TODO on flushOne -
+// this test exists so that gap is asserted deliberately instead of being
+// mistaken for durability.
+func TestDB_FlushDropsReadVisibilityUntilSSTables(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.Put("key", []byte("value")); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if err := db.Flush(); err != nil {
+		t.Fatalf("Flush() unexpected error: %v", err)
+	}
+
+	_, found, err := db.Get("key")
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if found {
+		t.Error("Get() found a key after Flush drained it - flushOne now persists data; update this test and its TODO")
+	}
+}
+
+func TestDB_FlushDrainsImmutableQueue(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.Put("key", []byte("value")); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if err := db.Flush(); err != nil {
+		t.Fatalf("Flush() unexpected error: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		db.mu.Lock()
+		drained := len(db.immutables) == 0
+		db.mu.Unlock()
+		if drained {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for the flusher to drain the immutable queue")
+		default:
+		}
+	}
+}
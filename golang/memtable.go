@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/binary"
 	"sync"
 	"sync/atomic"
 )
@@ -13,109 +14,227 @@ const (
 	typeDelete valueType = 2
 )
 
-// internalKV holds the value and its type (Put or Delete).
-// This allows us to store tombstones in the map.
-type internalKV struct {
-	value []byte
-	vtype valueType
-}
+// defaultArenaSize is the size of the Arena backing a new MemTable's
+// skiplist. It is deliberately generous relative to a typical flush
+// threshold so ordinary workloads never hit errArenaFull before the
+// memtable is flushed.
+const defaultArenaSize = 8 << 20 // 8MB
 
-// MemTable is an in-memory buffer for recent writes.
-// NOTE: This simple implementation uses a standard Go map and does NOT
-// maintain key order, which is a requirement for efficient flushing and
-// range scans in a real LSM Tree. A production implementation would
-// typically use an ordered data structure like a Skip List, BTree, Red-Black Tree, etc.
+// MemTable is an in-memory buffer for recent writes, backed by a concurrent
+// arena-allocated Skiplist. Unlike a plain map, entries stay sorted by key,
+// which is what makes it possible to flush a MemTable straight into a
+// sorted SSTable or serve an ordered range scan via NewIterator.
 type MemTable struct {
-	mu sync.RWMutex
-	kv map[string]internalKV
-
-	// Approximate size in bytes
+	skl *Skiplist
+	wal *WAL
+
+	// seq assigns each written entry a strictly increasing sequence
+	// number, so multiple versions of the same key can coexist in the
+	// skiplist and Get always finds the newest one first.
+	seq atomic.Uint64
+
+	// committedSeq is the highest sequence number whose entries have
+	// actually landed in the skiplist, as opposed to seq, which reserves a
+	// range before the WAL write and inserts for it happen. Snapshot reads
+	// this one: reading seq instead would let a snapshot pin a sequence
+	// number whose entries aren't spliced in yet, so a lookup at that
+	// snapshot could miss them now and then see them appear later, which
+	// breaks the "read view never changes" guarantee a snapshot promises.
+	committedSeq atomic.Uint64
+
+	// applyMu serializes ApplyBatch's reserve-write-insert-publish sequence,
+	// so batches are both inserted and published to committedSeq in the
+	// same order their sequence numbers were reserved in.
+	applyMu sync.Mutex
+
+	// count tracks the number of entries ever written, including
+	// tombstones and superseded versions, since inserts are append-only.
+	count atomic.Int64
+
+	// estimatedSize is the approximate number of bytes held by the
+	// skiplist's arena.
 	estimatedSize atomic.Int64
+
+	// snapshots tracks every currently-pinned read view of this MemTable.
+	snapshots *snapshotSet
 }
 
-func NewMemTable() *MemTable {
-	return &MemTable{
-		kv: make(map[string]internalKV),
+// NewMemTable creates a MemTable backed by a WAL rooted at walDir. If the
+// directory already holds WAL segments from a previous run, they are
+// replayed into the skiplist before NewMemTable returns, so the MemTable
+// picks up exactly where a crashed process left off.
+func NewMemTable(walDir string) (*MemTable, error) {
+	w, err := NewWAL(walDir, defaultWALSegmentSize)
+	if err != nil {
+		return nil, err
+	}
+
+	mt := &MemTable{
+		skl:       newSkiplist(NewArena(defaultArenaSize)),
+		wal:       w,
+		snapshots: newSnapshotSet(),
+	}
+	if err := mt.replayWAL(walDir); err != nil {
+		return nil, err
 	}
+	return mt, nil
 }
 
-// Put inserts or updates a key-value pair.
-func (mt *MemTable) Put(key string, value []byte) error {
-	mt.mu.Lock()
-	defer mt.mu.Unlock()
-
-	// Check if key exists to calculate size difference accurately
-	existing, exists := mt.kv[key]
-	var oldSize int64
-	if exists {
-		oldSize = int64(len(key) + len(existing.value)) // Approximate existing size
+// replayWAL reconstructs the skiplist from every record already on disk in
+// dir, preserving the original sequence numbers so versions replayed from
+// the log keep the same ordering they had before the crash.
+func (mt *MemTable) replayWAL(dir string) error {
+	r, err := NewReader(dir)
+	if err != nil {
+		return err
 	}
+	defer r.Close()
 
-	newValue := internalKV{
-		value: value,
-		vtype: typePut,
+	for r.Next() {
+		if err := mt.applyRecordBytes(r.Record()); err != nil {
+			return err
+		}
 	}
-	mt.kv[key] = newValue
+	return r.Err()
+}
 
-	// Update estimated size
-	newSize := int64(len(key) + len(value))
-	delta := newSize - oldSize
-	mt.estimatedSize.Add(delta)
+// applyRecordBytes installs a previously-logged, already seq-stamped batch
+// into the skiplist without writing it back to the WAL.
+func (mt *MemTable) applyRecordBytes(data []byte) error {
+	if len(data) < batchHeaderLen {
+		return errBatchTruncated
+	}
+	baseSeq := binary.LittleEndian.Uint64(data[0:8])
+
+	it := (&WriteBatch{data: data}).Iterator()
+	seq := baseSeq
+	var n, sizeDelta int64
+	for {
+		ok, err := it.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if err := mt.skl.insert(it.Key(), it.Value(), it.Kind(), seq); err != nil {
+			return err
+		}
+		sizeDelta += int64(len(it.Key()) + len(it.Value()))
+		seq++
+		n++
+	}
 
+	if n > 0 && mt.seq.Load() < seq-1 {
+		mt.seq.Store(seq - 1)
+		mt.committedSeq.Store(seq - 1)
+	}
+	mt.count.Add(n)
+	mt.estimatedSize.Add(sizeDelta)
 	return nil
 }
 
-// Delete marks a key as deleted (writes a tombstone).
+// Put inserts a new version of key with value. Because the skiplist is
+// append-only, this never mutates a previous version of key - it appends a
+// newer one ahead of it.
+func (mt *MemTable) Put(key string, value []byte) error {
+	b := NewWriteBatch()
+	b.Put([]byte(key), value)
+	return mt.ApplyBatch(b)
+}
+
+// Delete appends a tombstone for key, shadowing all earlier versions of it
+// without removing them from the skiplist.
 func (mt *MemTable) Delete(key string) error {
-	mt.mu.Lock()
-	defer mt.mu.Unlock()
-
-	// Check if key exists to calculate size difference accurately
-	existing, exists := mt.kv[key]
-	var oldSize int64
-	if exists {
-		// If it was already a tombstone, size calculation is different,
-		// but for simplicity, we'll approximate based on previous value size.
-		oldSize = int64(len(key) + len(existing.value))
+	b := NewWriteBatch()
+	b.Delete([]byte(key))
+	return mt.ApplyBatch(b)
+}
+
+// ApplyBatch writes b to the WAL and then installs every record in it
+// under a single sequence-number allocation, so the whole batch becomes
+// durable and visible to readers as one unit rather than record-by-record.
+// The estimated size is updated once at the end instead of per record,
+// which keeps large batches cache-friendly.
+//
+// The whole reserve-write-insert-publish sequence runs under applyMu, so a
+// concurrent Snapshot can never observe committedSeq covering a sequence
+// number whose entries aren't spliced into the skiplist yet.
+func (mt *MemTable) ApplyBatch(b *WriteBatch) error {
+	if b.Len() == 0 {
+		return nil
 	}
 
-	// Tombstone has nil value but typeDelete
-	tombstone := internalKV{
-		value: nil,
-		vtype: typeDelete,
+	mt.applyMu.Lock()
+	defer mt.applyMu.Unlock()
+
+	n := uint64(b.Len())
+	baseSeq := mt.seq.Add(n) - n + 1
+	b.setSeq(baseSeq)
+
+	if err := mt.wal.Write(b.Bytes()); err != nil {
+		return err
 	}
-	mt.kv[key] = tombstone
 
-	// Update estimated size - Tombstones still take up space (key + marker)
-	// We approximate tombstone value size as 0 here.
-	newSize := int64(len(key))
-	delta := newSize - oldSize
-	mt.estimatedSize.Add(delta)
+	it := b.Iterator()
+	seq := baseSeq
+	var sizeDelta int64
+	for {
+		ok, err := it.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if err := mt.skl.insert(it.Key(), it.Value(), it.Kind(), seq); err != nil {
+			return err
+		}
+		sizeDelta += int64(len(it.Key()) + len(it.Value()))
+		seq++
+	}
 
-	return nil // In this simple version, Delete always succeeds
+	mt.committedSeq.Store(seq - 1)
+	mt.count.Add(int64(b.Len()))
+	mt.estimatedSize.Add(sizeDelta)
+	return nil
 }
 
-// Get retrieves the value for a key.
+// Get retrieves the newest value for a key.
 // It returns the value, a boolean indicating if the key was found (and not deleted),
-// and an error (which is always nil in this simple version).
+// and an error, which is currently always nil.
 func (mt *MemTable) Get(key string) ([]byte, bool, error) {
-	mt.mu.RLock()
-	defer mt.mu.RUnlock()
+	value, found, _ := mt.getRaw(key)
+	return value, found, nil
+}
 
-	internalVal, exists := mt.kv[key]
-	if !exists {
-		return nil, false, nil // Not found
+// getRaw looks up key and, unlike Get, distinguishes an absent key from a
+// tombstone: isTombstone is true when a Delete for key is the newest
+// version found. DB uses this to know when to stop falling through to
+// older memtables - a tombstone shadows everything below it, even though
+// it carries no value of its own.
+func (mt *MemTable) getRaw(key string) (value []byte, found, isTombstone bool) {
+	// Read at committedSeq so a batch still being inserted by ApplyBatch
+	// stays invisible until all of its records have been spliced in.
+	n := mt.skl.getAtSeq([]byte(key), mt.committedSeq.Load())
+	if n == nil {
+		return nil, false, false
 	}
-
-	if internalVal.vtype == typeDelete {
-		return nil, false, nil // Found, but it's a tombstone (deleted)
+	if n.vtype == typeDelete {
+		return nil, false, true
 	}
 
-	// Found a regular PUT value
-	// Return a copy to prevent modification of internal slice
-	valueCopy := make([]byte, len(internalVal.value))
-	copy(valueCopy, internalVal.value)
-	return valueCopy, true, nil
+	// Return a copy so callers can't mutate arena-backed memory.
+	v := mt.skl.valueAt(n)
+	valueCopy := make([]byte, len(v))
+	copy(valueCopy, v)
+	return valueCopy, true, false
+}
+
+// NewIterator returns an Iterator over the MemTable in ascending key order,
+// suitable for flushing the memtable into a sorted SSTable.
+func (mt *MemTable) NewIterator() *Iterator {
+	return mt.skl.newIterator()
 }
 
 // Size returns the approximate size of the MemTable in bytes.
@@ -126,10 +245,9 @@ func (mt *MemTable) Size() int64 {
 	return mt.estimatedSize.Load()
 }
 
-// Len returns the number of entries (including tombstones) in the MemTable.
-// Useful for testing or simple metrics.
+// Len returns the number of entries ever written to the MemTable, including
+// tombstones and superseded versions, since the underlying skiplist never
+// overwrites or removes a node in place.
 func (mt *MemTable) Len() int {
-	mt.mu.RLock()
-	defer mt.mu.RUnlock()
-	return len(mt.kv)
+	return int(mt.count.Load())
 }
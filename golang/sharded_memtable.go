@@ -0,0 +1,196 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+)
+
+// ShardedMemTable fans writes out across N independent MemTable shards,
+// keyed by the low bits of an FNV-32a hash of the key. Each shard owns its
+// own skiplist, arena and WAL, so writers touching different shards never
+// contend with each other, and Size/Len simply sum each shard's
+// independently maintained atomic counters. It offers the same Put, Delete,
+// Get, ApplyBatch, Size and Len methods as a single MemTable; it has no
+// Snapshot, ReleaseSnapshot or GetAtSnapshot, and its NewIterator returns a
+// *MergedIterator rather than an *Iterator.
+type ShardedMemTable struct {
+	shards []*MemTable
+	mask   uint32
+}
+
+// NewShardedMemTable creates a ShardedMemTable with the given number of
+// shards, each rooted at its own subdirectory of walDir. shards must be a
+// power of two so shardIndex can mask instead of taking a modulus.
+func NewShardedMemTable(walDir string, shards int) (*ShardedMemTable, error) {
+	if shards <= 0 || shards&(shards-1) != 0 {
+		return nil, fmt.Errorf("sharded memtable: shards must be a power of two, got %d", shards)
+	}
+
+	smt := &ShardedMemTable{
+		shards: make([]*MemTable, shards),
+		mask:   uint32(shards - 1),
+	}
+	for i := range smt.shards {
+		mt, err := NewMemTable(filepath.Join(walDir, fmt.Sprintf("shard-%02d", i)))
+		if err != nil {
+			return nil, err
+		}
+		smt.shards[i] = mt
+	}
+	return smt, nil
+}
+
+// shardIndex picks the shard a key belongs to.
+func (smt *ShardedMemTable) shardIndex(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32() & smt.mask
+}
+
+func (smt *ShardedMemTable) shardFor(key string) *MemTable {
+	return smt.shards[smt.shardIndex(key)]
+}
+
+// Put routes key to its shard and inserts it there.
+func (smt *ShardedMemTable) Put(key string, value []byte) error {
+	return smt.shardFor(key).Put(key, value)
+}
+
+// Delete routes key to its shard and appends a tombstone there.
+func (smt *ShardedMemTable) Delete(key string) error {
+	return smt.shardFor(key).Delete(key)
+}
+
+// Get routes key to its shard and reads it there.
+func (smt *ShardedMemTable) Get(key string) ([]byte, bool, error) {
+	return smt.shardFor(key).Get(key)
+}
+
+// ApplyBatch splits b's records across shards by key and applies each
+// shard's share as its own sub-batch. Because each shard commits under its
+// own sequence-number allocation, a cross-shard batch is atomic per shard,
+// not as a single global unit.
+func (smt *ShardedMemTable) ApplyBatch(b *WriteBatch) error {
+	perShard := make([]*WriteBatch, len(smt.shards))
+
+	it := b.Iterator()
+	for {
+		ok, err := it.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		idx := smt.shardIndex(string(it.Key()))
+		if perShard[idx] == nil {
+			perShard[idx] = NewWriteBatch()
+		}
+		switch it.Kind() {
+		case typePut:
+			perShard[idx].Put(it.Key(), it.Value())
+		case typeDelete:
+			perShard[idx].Delete(it.Key())
+		}
+	}
+
+	for i, sb := range perShard {
+		if sb == nil {
+			continue
+		}
+		if err := smt.shards[i].ApplyBatch(sb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Size sums the approximate size of every shard.
+func (smt *ShardedMemTable) Size() int64 {
+	var total int64
+	for _, mt := range smt.shards {
+		total += mt.Size()
+	}
+	return total
+}
+
+// Len sums the entry count of every shard.
+func (smt *ShardedMemTable) Len() int {
+	var total int
+	for _, mt := range smt.shards {
+		total += mt.Len()
+	}
+	return total
+}
+
+// mergedIterHeap is a min-heap of shard iterators, ordered the same way a
+// single skiplist orders its nodes: key ascending, then seq descending.
+type mergedIterHeap []*Iterator
+
+func (h mergedIterHeap) Len() int { return len(h) }
+func (h mergedIterHeap) Less(i, j int) bool {
+	return compare(h[i].Key(), h[i].Seq(), h[j].Key(), h[j].Seq()) < 0
+}
+func (h mergedIterHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergedIterHeap) Push(x any)   { *h = append(*h, x.(*Iterator)) }
+func (h *mergedIterHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// MergedIterator merges the per-shard iterators of a ShardedMemTable into
+// a single externally-ordered stream, so a ShardedMemTable can be flushed
+// to a sorted SSTable exactly like a plain MemTable can.
+type MergedIterator struct {
+	h   mergedIterHeap
+	cur *Iterator
+}
+
+// NewIterator returns a MergedIterator over every shard in ascending key
+// order.
+func (smt *ShardedMemTable) NewIterator() *MergedIterator {
+	h := make(mergedIterHeap, 0, len(smt.shards))
+	for _, mt := range smt.shards {
+		it := mt.NewIterator()
+		if it.Next() {
+			h = append(h, it)
+		}
+	}
+	heap.Init(&h)
+	return &MergedIterator{h: h}
+}
+
+// Next advances the merged iterator and reports whether it landed on a
+// valid entry.
+func (mi *MergedIterator) Next() bool {
+	if mi.cur != nil {
+		if mi.cur.Next() {
+			heap.Push(&mi.h, mi.cur)
+		}
+	}
+	if mi.h.Len() == 0 {
+		mi.cur = nil
+		return false
+	}
+	mi.cur = heap.Pop(&mi.h).(*Iterator)
+	return true
+}
+
+// Key returns the key at the merged iterator's current position.
+func (mi *MergedIterator) Key() []byte { return mi.cur.Key() }
+
+// Value returns the value at the merged iterator's current position.
+func (mi *MergedIterator) Value() []byte { return mi.cur.Value() }
+
+// ValueType reports whether the current entry is a Put or a Delete
+// tombstone.
+func (mi *MergedIterator) ValueType() valueType { return mi.cur.ValueType() }
+
+// Seq returns the sequence number the current entry was written with.
+func (mi *MergedIterator) Seq() uint64 { return mi.cur.Seq() }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newTestShardedMemTable(t *testing.T, shards int) *ShardedMemTable {
+	t.Helper()
+	smt, err := NewShardedMemTable(t.TempDir(), shards)
+	if err != nil {
+		t.Fatalf("NewShardedMemTable() unexpected error: %v", err)
+	}
+	return smt
+}
+
+func TestShardedMemTable_PutGetAcrossShards(t *testing.T) {
+	smt := newTestShardedMemTable(t, 4)
+
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key-%02d", i)
+		if err := smt.Put(key, []byte(key)); err != nil {
+			t.Fatalf("Put(%q) unexpected error: %v", key, err)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key-%02d", i)
+		value, found, err := smt.Get(key)
+		if err != nil {
+			t.Fatalf("Get(%q) unexpected error: %v", key, err)
+		}
+		if !found || string(value) != key {
+			t.Errorf("Get(%q) = (%q, %v), want (%q, true)", key, value, found, key)
+		}
+	}
+	if got := smt.Len(); got != 20 {
+		t.Errorf("Len() = %d, want 20", got)
+	}
+}
+
+func TestNewShardedMemTable_RejectsNonPowerOfTwo(t *testing.T) {
+	if _, err := NewShardedMemTable(t.TempDir(), 3); err == nil {
+		t.Error("NewShardedMemTable(3 shards) = nil error, want an error")
+	}
+}
+
+func TestShardedMemTable_NewIteratorIsOrdered(t *testing.T) {
+	smt := newTestShardedMemTable(t, 4)
+
+	keys := []string{"delta", "bravo", "charlie", "alpha", "echo"}
+	for _, k := range keys {
+		if err := smt.Put(k, []byte(k)); err != nil {
+			t.Fatalf("Put(%q) unexpected error: %v", k, err)
+		}
+	}
+
+	it := smt.NewIterator()
+	var got []string
+	for it.Next() {
+		got = append(got, string(it.Key()))
+	}
+
+	want := []string{"alpha", "bravo", "charlie", "delta", "echo"}
+	if len(got) != len(want) {
+		t.Fatalf("merged iteration returned %d keys, want %d (%v)", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i] != w {
+			t.Errorf("merged iteration order[%d] = %q, want %q (full: %v)", i, got[i], w, got)
+		}
+	}
+}
+
+func TestShardedMemTable_ApplyBatchAcrossShards(t *testing.T) {
+	smt := newTestShardedMemTable(t, 4)
+
+	b := NewWriteBatch()
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("batch-%02d", i)
+		b.Put([]byte(key), []byte(key))
+	}
+
+	if err := smt.ApplyBatch(b); err != nil {
+		t.Fatalf("ApplyBatch() unexpected error: %v", err)
+	}
+	if got := smt.Len(); got != 10 {
+		t.Errorf("Len() after ApplyBatch = %d, want 10", got)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"sync/atomic"
+)
+
+// maxHeight bounds how many tower levels a node can have. 12 levels give a
+// skiplist headroom for roughly 4^12 entries at the p=0.25 branching factor
+// used by randomHeight, which is far beyond what a single MemTable holds
+// before it is flushed.
+const maxHeight = 12
+
+// pValue is the probability a node promotes to the next level, following
+// the branching factor used by Pebble's arenaskl and LevelDB's skiplist.
+const pValue = 0.25
+
+// node is a single entry in the skiplist. The key and value bytes it
+// references live in the owning Skiplist's Arena; only the node header
+// itself is a regular heap allocation. A node's key, value and metadata are
+// never mutated after it is spliced in, and its tower links are only changed
+// atomically, so readers can walk the tower without holding any lock.
+type node struct {
+	keyOffset uint32
+	keySize   uint32
+	valOffset uint32
+	valSize   uint32
+	vtype     valueType
+	seq       uint64
+
+	height int
+	tower  [maxHeight]atomic.Pointer[node]
+}
+
+// Skiplist is a concurrent, ordered, append-only index over versioned
+// key/value records. Keys are ordered ascending; among equal keys, entries
+// are ordered by descending seq so the newest version of a key is always
+// the first one encountered when walking forward from the head. Inserts
+// splice new nodes into each tower level with a compare-and-swap loop, so
+// writers never block readers and never block each other beyond retrying
+// a failed CAS.
+type Skiplist struct {
+	arena *Arena
+	head  *node
+}
+
+// newSkiplist creates an empty Skiplist backed by arena.
+func newSkiplist(arena *Arena) *Skiplist {
+	return &Skiplist{
+		arena: arena,
+		head:  &node{height: maxHeight},
+	}
+}
+
+// randomHeight picks a tower height using a geometric distribution with
+// branching factor pValue, so each additional level is four times less
+// likely than the last.
+func randomHeight() int {
+	h := 1
+	for h < maxHeight && rand.Float64() < pValue {
+		h++
+	}
+	return h
+}
+
+// keyAt returns the key bytes stored for n.
+func (s *Skiplist) keyAt(n *node) []byte {
+	return s.arena.getBytes(n.keyOffset, int(n.keySize))
+}
+
+// valueAt returns the value bytes stored for n.
+func (s *Skiplist) valueAt(n *node) []byte {
+	return s.arena.getBytes(n.valOffset, int(n.valSize))
+}
+
+// compare orders entries by key ascending, then by seq descending so the
+// newest version of a key sorts first.
+func compare(key []byte, seq uint64, otherKey []byte, otherSeq uint64) int {
+	if c := bytes.Compare(key, otherKey); c != 0 {
+		return c
+	}
+	switch {
+	case seq > otherSeq:
+		return -1
+	case seq < otherSeq:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// findSplice walks the tower from the top level down and returns, for every
+// level, the predecessor and successor of the position where a node with
+// the given (key, seq) belongs.
+func (s *Skiplist) findSplice(key []byte, seq uint64) (prev, next [maxHeight]*node) {
+	pred := s.head
+	for level := maxHeight - 1; level >= 0; level-- {
+		curr := pred.tower[level].Load()
+		for curr != nil && compare(s.keyAt(curr), curr.seq, key, seq) < 0 {
+			pred = curr
+			curr = pred.tower[level].Load()
+		}
+		prev[level] = pred
+		next[level] = curr
+	}
+	return prev, next
+}
+
+// insert appends a new versioned record to the skiplist. It never mutates
+// an existing node: a Put following a Delete for the same key (or vice
+// versa) simply becomes a new, newer node ahead of the old one.
+func (s *Skiplist) insert(key, value []byte, vtype valueType, seq uint64) error {
+	keyOffset, err := s.arena.putBytes(key)
+	if err != nil {
+		return err
+	}
+	valOffset, err := s.arena.putBytes(value)
+	if err != nil {
+		return err
+	}
+
+	n := &node{
+		keyOffset: keyOffset,
+		keySize:   uint32(len(key)),
+		valOffset: valOffset,
+		valSize:   uint32(len(value)),
+		vtype:     vtype,
+		seq:       seq,
+		height:    randomHeight(),
+	}
+
+	for level := 0; level < n.height; level++ {
+		for {
+			prev, next := s.findSplice(key, seq)
+			n.tower[level].Store(next[level])
+			if prev[level].tower[level].CompareAndSwap(next[level], n) {
+				break
+			}
+			// Another writer spliced in concurrently at this level; retry
+			// the search and CAS rather than blocking.
+		}
+	}
+	return nil
+}
+
+// get returns the newest node whose key equals key, or nil if no such node
+// exists.
+func (s *Skiplist) get(key []byte) *node {
+	return s.getAtSeq(key, math.MaxUint64)
+}
+
+// getAtSeq returns the newest node for key whose seq is <= maxSeq, or nil
+// if no such node exists. This is what gives MVCC reads their snapshot
+// isolation: findSplice locates the position a pseudo-node (key, maxSeq)
+// would be inserted at, which - because nodes for the same key are
+// ordered by seq descending - is exactly the position of the newest
+// version of key no newer than maxSeq.
+func (s *Skiplist) getAtSeq(key []byte, maxSeq uint64) *node {
+	_, next := s.findSplice(key, maxSeq)
+	candidate := next[0]
+	if candidate != nil && bytes.Equal(s.keyAt(candidate), key) {
+		return candidate
+	}
+	return nil
+}
+
+// Iterator walks a Skiplist in key order (ascending key, then descending
+// seq), which is exactly the order a MemTable must be flushed in to
+// produce a sorted SSTable.
+type Iterator struct {
+	skl *Skiplist
+	cur *node
+}
+
+// newIterator returns an Iterator positioned before the first entry; call
+// Next to advance to it.
+func (s *Skiplist) newIterator() *Iterator {
+	return &Iterator{skl: s, cur: s.head}
+}
+
+// Next advances the iterator and reports whether it landed on a valid
+// entry.
+func (it *Iterator) Next() bool {
+	next := it.cur.tower[0].Load()
+	if next == nil {
+		return false
+	}
+	it.cur = next
+	return true
+}
+
+// Valid reports whether the iterator is currently positioned on an entry.
+func (it *Iterator) Valid() bool {
+	return it.cur != it.skl.head
+}
+
+// Key returns the key at the iterator's current position.
+func (it *Iterator) Key() []byte {
+	return it.skl.keyAt(it.cur)
+}
+
+// Value returns the value at the iterator's current position.
+func (it *Iterator) Value() []byte {
+	return it.skl.valueAt(it.cur)
+}
+
+// ValueType reports whether the current entry is a Put or a Delete
+// tombstone.
+func (it *Iterator) ValueType() valueType {
+	return it.cur.vtype
+}
+
+// Seq returns the sequence number the current entry was written with.
+func (it *Iterator) Seq() uint64 {
+	return it.cur.seq
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSkiplist_OrderedIteration(t *testing.T) {
+	skl := newSkiplist(NewArena(4096))
+
+	keys := []string{"banana", "apple", "cherry"}
+	for i, k := range keys {
+		if err := skl.insert([]byte(k), []byte("v"), typePut, uint64(i+1)); err != nil {
+			t.Fatalf("insert(%q) unexpected error: %v", k, err)
+		}
+	}
+
+	it := skl.newIterator()
+	want := []string{"apple", "banana", "cherry"}
+	for _, w := range want {
+		if !it.Next() {
+			t.Fatalf("expected more entries, wanted %q", w)
+		}
+		if got := string(it.Key()); got != w {
+			t.Errorf("iteration order mismatch: got %q, want %q", got, w)
+		}
+	}
+	if it.Next() {
+		t.Errorf("expected iteration to end, got extra key %q", it.Key())
+	}
+}
+
+func TestSkiplist_GetReturnsNewestVersion(t *testing.T) {
+	skl := newSkiplist(NewArena(4096))
+
+	key := []byte("key")
+	if err := skl.insert(key, []byte("old"), typePut, 1); err != nil {
+		t.Fatalf("insert unexpected error: %v", err)
+	}
+	if err := skl.insert(key, []byte("new"), typePut, 2); err != nil {
+		t.Fatalf("insert unexpected error: %v", err)
+	}
+
+	n := skl.get(key)
+	if n == nil {
+		t.Fatalf("get(%q) = nil, want a node", key)
+	}
+	if !bytes.Equal(skl.valueAt(n), []byte("new")) {
+		t.Errorf("get(%q) value = %q, want %q", key, skl.valueAt(n), "new")
+	}
+	if n.seq != 2 {
+		t.Errorf("get(%q) seq = %d, want 2", key, n.seq)
+	}
+}
+
+func TestArena_AllocFull(t *testing.T) {
+	arena := NewArena(4)
+
+	if _, err := arena.putBytes([]byte("ab")); err != nil {
+		t.Fatalf("putBytes unexpected error: %v", err)
+	}
+	if _, err := arena.putBytes([]byte("abc")); err != errArenaFull {
+		t.Errorf("putBytes over capacity error = %v, want %v", err, errArenaFull)
+	}
+}
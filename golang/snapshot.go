@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math"
+	"sync"
+)
+
+// Snapshot is a point-in-time read view of a MemTable: a lookup made
+// against it only sees versions written at or before the snapshot's
+// sequence number, as if later writes - including tombstones - had not
+// happened yet.
+type Snapshot struct {
+	seq uint64
+}
+
+// snapshotSet tracks every currently-pinned Snapshot for a MemTable, so a
+// future compactor can ask for the oldest sequence number still observable
+// by a reader and avoid reclaiming versions newer than it.
+type snapshotSet struct {
+	mu   sync.Mutex
+	live map[*Snapshot]struct{}
+}
+
+func newSnapshotSet() *snapshotSet {
+	return &snapshotSet{live: make(map[*Snapshot]struct{})}
+}
+
+func (s *snapshotSet) add(snap *Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.live[snap] = struct{}{}
+}
+
+func (s *snapshotSet) remove(snap *Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.live, snap)
+}
+
+// minPinnedSeq returns the oldest sequence number still referenced by a
+// live snapshot, or math.MaxUint64 if none are pinned.
+func (s *snapshotSet) minPinnedSeq() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	min := uint64(math.MaxUint64)
+	for snap := range s.live {
+		if snap.seq < min {
+			min = snap.seq
+		}
+	}
+	return min
+}
+
+// Snapshot captures the MemTable's current committed sequence number and
+// pins it, so a future compactor can tell (via minPinnedSeq) that versions
+// up to and including this one might still be needed by a reader. It reads
+// committedSeq rather than the raw allocation counter, since the latter is
+// bumped before a batch's entries are inserted - pinning it could capture a
+// sequence number the skiplist doesn't actually hold yet. Call
+// ReleaseSnapshot once the snapshot is no longer needed.
+func (mt *MemTable) Snapshot() *Snapshot {
+	snap := &Snapshot{seq: mt.committedSeq.Load()}
+	mt.snapshots.add(snap)
+	return snap
+}
+
+// ReleaseSnapshot unpins a snapshot previously returned by Snapshot.
+func (mt *MemTable) ReleaseSnapshot(snap *Snapshot) {
+	mt.snapshots.remove(snap)
+}
+
+// GetAtSnapshot retrieves the newest version of key visible at snap,
+// ignoring any write - including a tombstone - made after the snapshot was
+// taken. This is the foundation for consistent iterators and
+// read-your-writes semantics on top of a MemTable.
+func (mt *MemTable) GetAtSnapshot(key string, snap *Snapshot) ([]byte, bool, error) {
+	n := mt.skl.getAtSeq([]byte(key), snap.seq)
+	if n == nil {
+		return nil, false, nil
+	}
+	if n.vtype == typeDelete {
+		return nil, false, nil
+	}
+
+	value := mt.skl.valueAt(n)
+	valueCopy := make([]byte, len(value))
+	copy(valueCopy, value)
+	return valueCopy, true, nil
+}
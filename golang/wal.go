@@ -0,0 +1,468 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// defaultWALSegmentSize is the size a WAL segment file is rotated at.
+const defaultWALSegmentSize = 128 << 20 // 128MB
+
+// Record types used to frame WAL payloads, so a single logical record can
+// be split across segment boundaries without losing the ability to
+// reassemble it on replay.
+const (
+	recTypeFull   byte = 1
+	recTypeFirst  byte = 2
+	recTypeMiddle byte = 3
+	recTypeLast   byte = 4
+)
+
+// walFrameHeaderLen is [type:1][len:2][crc32:4].
+const walFrameHeaderLen = 1 + 2 + 4
+
+// maxFrameLen is the largest payload a single frame can carry, bounded by
+// the 2-byte len field. Write splits anything bigger across multiple
+// first/middle/last frames, the same way it already splits a record
+// across segment boundaries.
+const maxFrameLen = 1<<16 - 1
+
+var errWALChecksumMismatch = errors.New("wal: checksum mismatch")
+
+// WAL is a write-ahead log: every mutation is framed and appended here
+// before it touches the in-memory MemTable, so the memtable can be
+// reconstructed by replaying the log after a crash. It rotates into a new
+// segment file once the current one reaches segmentSize.
+type WAL struct {
+	dir         string
+	segmentSize int64
+
+	mu           sync.Mutex
+	cur          *os.File
+	curSize      int64
+	segmentIndex int
+}
+
+// NewWAL opens (or creates) a WAL rooted at dir. If segmentSize is <= 0,
+// defaultWALSegmentSize is used. If segments already exist in dir, writing
+// resumes by appending to the last one.
+func NewWAL(dir string, segmentSize int64) (*WAL, error) {
+	if segmentSize <= 0 {
+		segmentSize = defaultWALSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	w := &WAL{dir: dir, segmentSize: segmentSize, segmentIndex: -1}
+
+	segments, err := listWALSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		if err := w.rotate(); err != nil {
+			return nil, err
+		}
+		return w, nil
+	}
+
+	last := segments[len(segments)-1]
+	path := segmentPath(dir, last)
+
+	validSize, err := validSegmentPrefix(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Truncate(path, validSize); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	w.segmentIndex = last
+	w.cur = f
+	w.curSize = validSize
+	return w, nil
+}
+
+// validSegmentPrefix scans path frame by frame and returns the byte offset
+// immediately following the last fully valid frame. A crash mid-write can
+// leave a torn header, a truncated payload, or a payload whose CRC no
+// longer matches at the tail of the file; appending new frames after that
+// garbage instead of before it would make every record that follows
+// unreadable on the next recovery, so the caller truncates to this offset
+// before resuming writes.
+func validSegmentPrefix(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var offset int64
+	header := make([]byte, walFrameHeaderLen)
+	for {
+		if _, err := io.ReadFull(f, header); err != nil {
+			break // torn or absent header: stop before it
+		}
+		length := binary.LittleEndian.Uint16(header[1:3])
+		wantCRC := binary.LittleEndian.Uint32(header[3:7])
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(f, payload); err != nil {
+			break // torn payload: stop before the frame that contains it
+		}
+		if crc32.ChecksumIEEE(payload) != wantCRC {
+			break // corrupt payload: stop before the frame that contains it
+		}
+		offset += int64(walFrameHeaderLen) + int64(length)
+	}
+	return offset, nil
+}
+
+// Write frames data and appends it to the log, splitting it across as many
+// records (and segment files) as needed. Callers that need a durability
+// guarantee beyond the OS page cache should follow up with Sync. Write may
+// be called concurrently; writes are serialized internally.
+func (w *WAL) Write(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	remaining := data
+	first := true
+	for {
+		if w.segmentSize-w.curSize <= walFrameHeaderLen {
+			if err := w.rotate(); err != nil {
+				return err
+			}
+		}
+
+		avail := w.segmentSize - w.curSize - walFrameHeaderLen
+		if avail > maxFrameLen {
+			avail = maxFrameLen
+		}
+		chunk := remaining
+		last := true
+		if int64(len(remaining)) > avail {
+			chunk = remaining[:avail]
+			last = false
+		}
+
+		var typ byte
+		switch {
+		case first && last:
+			typ = recTypeFull
+		case first:
+			typ = recTypeFirst
+		case last:
+			typ = recTypeLast
+		default:
+			typ = recTypeMiddle
+		}
+		if err := w.writeFrame(typ, chunk); err != nil {
+			return err
+		}
+
+		remaining = remaining[len(chunk):]
+		first = false
+		if last {
+			return nil
+		}
+	}
+}
+
+func (w *WAL) writeFrame(typ byte, payload []byte) error {
+	header := make([]byte, walFrameHeaderLen)
+	header[0] = typ
+	binary.LittleEndian.PutUint16(header[1:3], uint16(len(payload)))
+	binary.LittleEndian.PutUint32(header[3:7], crc32.ChecksumIEEE(payload))
+
+	if _, err := w.cur.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.cur.Write(payload); err != nil {
+		return err
+	}
+	w.curSize += int64(len(header) + len(payload))
+	return nil
+}
+
+// rotate seals the current segment (fsyncing it) and opens a new one.
+func (w *WAL) rotate() error {
+	if w.cur != nil {
+		if err := w.cur.Sync(); err != nil {
+			return err
+		}
+		if err := w.cur.Close(); err != nil {
+			return err
+		}
+	}
+
+	w.segmentIndex++
+	f, err := os.OpenFile(segmentPath(w.dir, w.segmentIndex), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w.cur = f
+	w.curSize = 0
+	return nil
+}
+
+// Sync fsyncs the current segment on demand, e.g. after a batch of writes
+// the caller wants durable before acknowledging them.
+func (w *WAL) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cur == nil {
+		return nil
+	}
+	return w.cur.Sync()
+}
+
+// Close fsyncs and closes the current segment.
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cur == nil {
+		return nil
+	}
+	if err := w.cur.Sync(); err != nil {
+		return err
+	}
+	return w.cur.Close()
+}
+
+func segmentPath(dir string, index int) string {
+	return filepath.Join(dir, fmt.Sprintf("%08d.wal", index))
+}
+
+// listWALSegments returns the indices of segment files already present in
+// dir, ascending.
+func listWALSegments(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var indices []int
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".wal" {
+			continue
+		}
+		var idx int
+		if _, err := fmt.Sscanf(e.Name(), "%08d.wal", &idx); err == nil {
+			indices = append(indices, idx)
+		}
+	}
+	sort.Ints(indices)
+	return indices, nil
+}
+
+// Reader replays every sealed WAL record in a directory, in the order it
+// was written, for offline crash recovery. A trailing record left
+// truncated by a crash mid-write is treated as the end of the log rather
+// than an error.
+type Reader struct {
+	segmentFiles []string
+	idx          int
+	f            *os.File
+	pending      []byte
+	rec          []byte
+	err          error
+}
+
+// NewReader returns a Reader over every segment file currently in dir.
+func NewReader(dir string) (*Reader, error) {
+	segments, err := listWALSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(segments))
+	for i, s := range segments {
+		paths[i] = segmentPath(dir, s)
+	}
+	return &Reader{segmentFiles: paths, idx: -1}, nil
+}
+
+// Next reads and reassembles the next logical record and reports whether
+// one was found. Once it returns false, check Err to distinguish a clean
+// end of log from a read failure.
+func (r *Reader) Next() bool {
+	for {
+		if r.f == nil {
+			if !r.openNextSegment() {
+				return false
+			}
+		}
+
+		header := make([]byte, walFrameHeaderLen)
+		if _, err := io.ReadFull(r.f, header); err != nil {
+			if err == io.EOF {
+				r.f.Close()
+				r.f = nil
+				continue
+			}
+			if err == io.ErrUnexpectedEOF {
+				// A partially written frame header, left behind by a
+				// crash mid-write. Treat it as the end of the log.
+				return false
+			}
+			r.err = err
+			return false
+		}
+
+		typ := header[0]
+		length := binary.LittleEndian.Uint16(header[1:3])
+		wantCRC := binary.LittleEndian.Uint32(header[3:7])
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r.f, payload); err != nil {
+			if err == io.ErrUnexpectedEOF || err == io.EOF {
+				// A frame whose payload was cut short (or never written)
+				// by a crash mid-write. Treat it as the end of the log.
+				return false
+			}
+			r.err = err
+			return false
+		}
+		if crc32.ChecksumIEEE(payload) != wantCRC {
+			r.err = errWALChecksumMismatch
+			return false
+		}
+
+		switch typ {
+		case recTypeFull:
+			r.rec = payload
+			return true
+		case recTypeFirst:
+			r.pending = append([]byte(nil), payload...)
+		case recTypeMiddle:
+			r.pending = append(r.pending, payload...)
+		case recTypeLast:
+			r.pending = append(r.pending, payload...)
+			r.rec = r.pending
+			r.pending = nil
+			return true
+		}
+	}
+}
+
+func (r *Reader) openNextSegment() bool {
+	r.idx++
+	if r.idx >= len(r.segmentFiles) {
+		return false
+	}
+	f, err := os.Open(r.segmentFiles[r.idx])
+	if err != nil {
+		r.err = err
+		return false
+	}
+	r.f = f
+	return true
+}
+
+// Record returns the logical record Next just landed on.
+func (r *Reader) Record() []byte { return r.rec }
+
+// Err returns the first error encountered, if any.
+func (r *Reader) Err() error { return r.err }
+
+// Close releases the currently open segment file, if any.
+func (r *Reader) Close() error {
+	if r.f != nil {
+		return r.f.Close()
+	}
+	return nil
+}
+
+// LiveReader tails a single WAL segment file that may still be actively
+// written to. Unlike Reader, a false return from Next does not mean there
+// will never be more data: it means there isn't any /yet/. The caller can
+// retry Next later once the writer has appended more bytes, which is what
+// makes LiveReader suitable for tailing the log, e.g. for replication.
+type LiveReader struct {
+	f       *os.File
+	offset  int64
+	pending []byte
+	rec     []byte
+	err     error
+}
+
+// NewLiveReader returns a LiveReader that tails f starting at the beginning
+// of the file. It reads with ReadAt, so f's own read offset is neither used
+// nor moved.
+func NewLiveReader(f *os.File) *LiveReader {
+	return &LiveReader{f: f}
+}
+
+// Next reads and reassembles the next logical record. It returns false
+// both when a frame is incomplete (retry later) and when a hard error
+// occurred (check Err).
+func (r *LiveReader) Next() bool {
+	for {
+		header := make([]byte, walFrameHeaderLen)
+		n, err := r.f.ReadAt(header, r.offset)
+		if err != nil && err != io.EOF {
+			r.err = err
+			return false
+		}
+		if n < walFrameHeaderLen {
+			return false // header not fully written yet
+		}
+
+		typ := header[0]
+		length := binary.LittleEndian.Uint16(header[1:3])
+		wantCRC := binary.LittleEndian.Uint32(header[3:7])
+
+		payload := make([]byte, length)
+		n, err = r.f.ReadAt(payload, r.offset+walFrameHeaderLen)
+		if err != nil && err != io.EOF {
+			r.err = err
+			return false
+		}
+		if n < int(length) {
+			return false // payload not fully written yet
+		}
+		if crc32.ChecksumIEEE(payload) != wantCRC {
+			r.err = errWALChecksumMismatch
+			return false
+		}
+		r.offset += int64(walFrameHeaderLen) + int64(length)
+
+		switch typ {
+		case recTypeFull:
+			r.rec = payload
+			return true
+		case recTypeFirst:
+			r.pending = append([]byte(nil), payload...)
+		case recTypeMiddle:
+			r.pending = append(r.pending, payload...)
+		case recTypeLast:
+			r.pending = append(r.pending, payload...)
+			r.rec = r.pending
+			r.pending = nil
+			return true
+		default:
+			// Unwritten tail (zero bytes): step back so a retry rereads
+			// this position once the writer has filled it in.
+			r.offset -= int64(walFrameHeaderLen) + int64(length)
+			return false
+		}
+	}
+}
+
+// Record returns the logical record Next just landed on.
+func (r *LiveReader) Record() []byte { return r.rec }
+
+// Err returns the first hard error encountered, if any.
+func (r *LiveReader) Err() error { return r.err }
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestWAL_WriteAndReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := NewWAL(dir, 0)
+	if err != nil {
+		t.Fatalf("NewWAL() unexpected error: %v", err)
+	}
+	if err := w.Write([]byte("record-one")); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if err := w.Write([]byte("record-two")); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	r, err := NewReader(dir)
+	if err != nil {
+		t.Fatalf("NewReader() unexpected error: %v", err)
+	}
+	defer r.Close()
+
+	want := [][]byte{[]byte("record-one"), []byte("record-two")}
+	for _, w := range want {
+		if !r.Next() {
+			t.Fatalf("Next() = false, want true (err=%v)", r.Err())
+		}
+		if !bytes.Equal(r.Record(), w) {
+			t.Errorf("Record() = %q, want %q", r.Record(), w)
+		}
+	}
+	if r.Next() {
+		t.Errorf("expected no more records, got %q", r.Record())
+	}
+	if r.Err() != nil {
+		t.Errorf("Err() = %v, want nil", r.Err())
+	}
+}
+
+func TestWAL_SplitsAcrossSegments(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := NewWAL(dir, 32)
+	if err != nil {
+		t.Fatalf("NewWAL() unexpected error: %v", err)
+	}
+	payload := bytes.Repeat([]byte("x"), 100)
+	if err := w.Write(payload); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	segments, err := listWALSegments(dir)
+	if err != nil {
+		t.Fatalf("listWALSegments() unexpected error: %v", err)
+	}
+	if len(segments) < 2 {
+		t.Fatalf("expected the record to span multiple segments, got %d", len(segments))
+	}
+
+	r, err := NewReader(dir)
+	if err != nil {
+		t.Fatalf("NewReader() unexpected error: %v", err)
+	}
+	defer r.Close()
+
+	if !r.Next() {
+		t.Fatalf("Next() = false, want true (err=%v)", r.Err())
+	}
+	if !bytes.Equal(r.Record(), payload) {
+		t.Errorf("Record() length = %d, want %d", len(r.Record()), len(payload))
+	}
+}
+
+func TestWAL_SplitsRecordLargerThanMaxFrameLen(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := NewWAL(dir, 0)
+	if err != nil {
+		t.Fatalf("NewWAL() unexpected error: %v", err)
+	}
+	payload := bytes.Repeat([]byte("a"), maxFrameLen+5000)
+	if err := w.Write(payload); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	r, err := NewReader(dir)
+	if err != nil {
+		t.Fatalf("NewReader() unexpected error: %v", err)
+	}
+	defer r.Close()
+
+	if !r.Next() {
+		t.Fatalf("Next() = false, want true (err=%v)", r.Err())
+	}
+	if !bytes.Equal(r.Record(), payload) {
+		t.Errorf("Record() length = %d, want %d", len(r.Record()), len(payload))
+	}
+	if r.Err() != nil {
+		t.Errorf("Err() = %v, want nil", r.Err())
+	}
+}
+
+func TestWAL_ResumeTruncatesTornTailFrame(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := NewWAL(dir, 0)
+	if err != nil {
+		t.Fatalf("NewWAL() unexpected error: %v", err)
+	}
+	if err := w.Write([]byte("AAAA")); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	// Simulate a crash mid-write: a frame header claiming a payload that
+	// was never fully flushed to disk.
+	segments, err := listWALSegments(dir)
+	if err != nil {
+		t.Fatalf("listWALSegments() unexpected error: %v", err)
+	}
+	path := segmentPath(dir, segments[len(segments)-1])
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile() unexpected error: %v", err)
+	}
+	torn := []byte{recTypeFull, 0xFF, 0xFF, 0, 0, 0, 0} // len=65535, no payload, bogus crc
+	if _, err := f.Write(torn); err != nil {
+		t.Fatalf("Write(torn) unexpected error: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	w, err = NewWAL(dir, 0)
+	if err != nil {
+		t.Fatalf("NewWAL() (resume) unexpected error: %v", err)
+	}
+	if err := w.Write([]byte("DDDD")); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	r, err := NewReader(dir)
+	if err != nil {
+		t.Fatalf("NewReader() unexpected error: %v", err)
+	}
+	defer r.Close()
+
+	want := [][]byte{[]byte("AAAA"), []byte("DDDD")}
+	for _, w := range want {
+		if !r.Next() {
+			t.Fatalf("Next() = false, want true (err=%v)", r.Err())
+		}
+		if !bytes.Equal(r.Record(), w) {
+			t.Errorf("Record() = %q, want %q", r.Record(), w)
+		}
+	}
+	if r.Next() {
+		t.Errorf("expected no more records, got %q", r.Record())
+	}
+	if r.Err() != nil {
+		t.Errorf("Err() = %v, want nil", r.Err())
+	}
+}
+
+func TestMemTable_ReplaysWALAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	mt1, err := NewMemTable(dir)
+	if err != nil {
+		t.Fatalf("NewMemTable() unexpected error: %v", err)
+	}
+	if err := mt1.Put("key", []byte("value")); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if err := mt1.Delete("other"); err != nil {
+		t.Fatalf("Delete() unexpected error: %v", err)
+	}
+	if err := mt1.wal.Close(); err != nil {
+		t.Fatalf("wal.Close() unexpected error: %v", err)
+	}
+
+	mt2, err := NewMemTable(dir)
+	if err != nil {
+		t.Fatalf("NewMemTable() (recovery) unexpected error: %v", err)
+	}
+	checkGet(t, mt2, "key", []byte("value"), true)
+	checkGet(t, mt2, "other", nil, false)
+	if got := mt2.Len(); got != 2 {
+		t.Errorf("Len() after replay = %d, want 2", got)
+	}
+}